@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/simulot/immich-go/internal/metadata"
@@ -17,6 +18,9 @@ type GoogleMetaData struct {
 	Category           string             `json:"category"`
 	Date               *googTimeObject    `json:"date,omitempty"`
 	PhotoTakenTime     *googTimeObject    `json:"photoTakenTime"`
+	CreationTime       *googTimeObject    `json:"creationTime,omitempty"`     // set by the newer export pipeline and the Library API, used when PhotoTakenTime is absent
+	ModificationTime   *googTimeObject    `json:"modificationTime,omitempty"` // set by the newer export pipeline
+	Views              googIntString      `json:"imageViews,omitempty"`       // string-encoded in newer exports
 	GeoDataExif        *googGeoData       `json:"geoDataExif"`
 	GeoData            *googGeoData       `json:"geoData"`
 	Trashed            bool               `json:"trashed,omitempty"`
@@ -60,6 +64,9 @@ func (gmd GoogleMetaData) LogValue() slog.Value {
 		slog.String("Category", gmd.Category),
 		slog.Any("Date", gmd.Date),
 		slog.Any("PhotoTakenTime", gmd.PhotoTakenTime),
+		slog.Any("CreationTime", gmd.CreationTime),
+		slog.Any("ModificationTime", gmd.ModificationTime),
+		slog.Int("Views", int(gmd.Views)),
 		slog.Any("GeoDataExif", gmd.GeoDataExif),
 		slog.Any("GeoData", gmd.GeoData),
 		slog.Bool("Trashed", gmd.Trashed),
@@ -78,13 +85,25 @@ func (gmd GoogleMetaData) AsMetadata() *metadata.Metadata {
 	}
 
 	t := time.Time{}
-	if gmd.PhotoTakenTime != nil && gmd.PhotoTakenTime.Timestamp != "" && gmd.PhotoTakenTime.Timestamp != "0" {
+	switch {
+	case gmd.PhotoTakenTime != nil && gmd.PhotoTakenTime.Timestamp != "" && gmd.PhotoTakenTime.Timestamp != "0":
 		t = gmd.PhotoTakenTime.Time()
+	case gmd.CreationTime != nil && gmd.CreationTime.Timestamp != "" && gmd.CreationTime.Timestamp != "0":
+		// Newer Takeout exports and the Library API omit photoTakenTime and
+		// report creationTime instead.
+		t = gmd.CreationTime.Time()
+	}
+
+	description := gmd.Description
+	if gmd.Enrichments != nil {
+		if enrichmentText := gmd.Enrichments.Description(); enrichmentText != "" {
+			description = addString(description, "\n\n", enrichmentText)
+		}
 	}
 
 	return &metadata.Metadata{
 		FileName:    gmd.Title,
-		Description: gmd.Description,
+		Description: description,
 		DateTaken:   t,
 		Latitude:    latitude,
 		Longitude:   longitude,
@@ -102,11 +121,19 @@ func (gmd *GoogleMetaData) isAlbum() bool {
 	return gmd.Date.Timestamp != ""
 }
 
+// isAsset reports whether gmd describes a media file rather than an album,
+// recognizing either the older PhotoTakenTime field or the CreationTime
+// field newer export pipelines and the Library API use instead, so a
+// creationTime-only sidecar isn't silently excluded from features (such as
+// the hash sidecar matcher) that gate on isAsset.
 func (gmd *GoogleMetaData) isAsset() bool {
-	if gmd == nil || gmd.PhotoTakenTime == nil {
+	if gmd == nil {
 		return false
 	}
-	return gmd.PhotoTakenTime.Timestamp != ""
+	if gmd.PhotoTakenTime != nil && gmd.PhotoTakenTime.Timestamp != "" {
+		return true
+	}
+	return gmd.CreationTime != nil && gmd.CreationTime.Timestamp != ""
 }
 
 func (gmd *GoogleMetaData) isPartner() bool {
@@ -117,9 +144,18 @@ func (gmd *GoogleMetaData) isPartner() bool {
 }
 
 // Key return an expected unique key for the asset
-// based on the title and the timestamp
+// based on the title and the timestamp. PhotoTakenTime is absent on sidecars
+// produced by the newer export pipeline and the Library API, so it falls
+// back to CreationTime the same way AsMetadata does.
 func (gmd GoogleMetaData) Key() string {
-	return fmt.Sprintf("%s,%s", gmd.Title, gmd.PhotoTakenTime.Timestamp)
+	var timestamp string
+	switch {
+	case gmd.PhotoTakenTime != nil:
+		timestamp = gmd.PhotoTakenTime.Timestamp
+	case gmd.CreationTime != nil:
+		timestamp = gmd.CreationTime.Timestamp
+	}
+	return fmt.Sprintf("%s,%s", gmd.Title, timestamp)
 }
 
 // googIsPresent is set when the field is present. The content of the field is not relevant
@@ -143,6 +179,30 @@ func (p googIsPresent) MarshalJSON() ([]byte, error) {
 	return json.Marshal(struct{}{})
 }
 
+// googIntString is an integer that newer Takeout exports encode as a quoted
+// string (e.g. imageViews: "42"), while older ones may still send a bare
+// number.
+type googIntString int
+
+func (n *googIntString) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*n = googIntString(v)
+		return nil
+	}
+
+	var i int
+	if err := json.Unmarshal(b, &i); err != nil {
+		return err
+	}
+	*n = googIntString(i)
+	return nil
+}
+
 // googGeoData contains GPS coordinates
 type googGeoData struct {
 	Latitude  float64 `json:"latitude"`
@@ -167,6 +227,34 @@ type googTimeObject struct {
 	// Formatted string    `json:"formatted"`
 }
 
+// UnmarshalJSON accepts the usual {"timestamp": "1234", "formatted": "..."}
+// object, the same object with a bare numeric timestamp, and a raw RFC3339
+// string as returned directly by the Library API's creationTime field.
+func (gt *googTimeObject) UnmarshalJSON(b []byte) error {
+	type obj struct {
+		Timestamp json.RawMessage `json:"timestamp"`
+	}
+	var o obj
+	if err := json.Unmarshal(b, &o); err == nil && len(o.Timestamp) > 0 {
+		return gt.setTimestamp(o.Timestamp)
+	}
+	return gt.setTimestamp(b)
+}
+
+func (gt *googTimeObject) setTimestamp(raw json.RawMessage) error {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		gt.Timestamp = s
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(raw, &n); err == nil {
+		gt.Timestamp = n.String()
+		return nil
+	}
+	return fmt.Errorf("googTimeObject: unsupported timestamp value: %s", string(raw))
+}
+
 func (gt *googTimeObject) LogValue() slog.Value {
 	if gt == nil {
 		return slog.Value{}
@@ -174,75 +262,238 @@ func (gt *googTimeObject) LogValue() slog.Value {
 	return slog.TimeValue(gt.Time())
 }
 
-// Time return the time.Time of the epoch
+// Time return the time.Time of the epoch. The timestamp is usually a Unix
+// epoch in seconds, but the Library API's creationTime is an RFC3339
+// string, so that form is tried first.
 func (gt googTimeObject) Time() time.Time {
+	if gt.Timestamp == "" {
+		return time.Time{}
+	}
+	if t, err := time.Parse(time.RFC3339, gt.Timestamp); err == nil {
+		return t
+	}
 	ts, _ := strconv.ParseInt(gt.Timestamp, 10, 64)
 	if ts == 0 {
 		return time.Time{}
 	}
 	t := time.Unix(ts, 0)
 	local, _ := tzone.Local()
-	//	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
 	return t.In(local)
 }
 
-type googleEnrichments struct {
-	Text      string
-	Latitude  float64
-	Longitude float64
+// googleEnrichments preserves the ordered list of enrichment entries found
+// in an album's metadata.json, as Takeout writes one entry per narrative
+// text block, location pin or embedded map, in display order. Earlier
+// revisions flattened this into a single Text/Latitude/Longitude triplet,
+// which silently dropped every location but the last when an album had
+// several.
+type googleEnrichments []enrichmentEntry
+
+// enrichmentEntry is one element of an album's enrichments array. Exactly
+// one of the typed fields is set, matching whichever enrichment variant
+// Takeout emitted for that entry.
+type enrichmentEntry struct {
+	Narrative *NarrativeEntry
+	Location  *LocationEntry
+	Map       *MapEntry
+}
+
+// NarrativeEntry is a free-text caption the user attached to the album.
+type NarrativeEntry struct {
+	Text string
+}
+
+// LocationEntry is a single named place pinned on the album, as produced by
+// Google Photos' "add a place" enrichment.
+type LocationEntry struct {
+	Name        string
+	Description string
+	Lat         float64
+	Lon         float64
+}
+
+// MapEntry is a route drawn between two places (Takeout's mapEnrichment),
+// kept as its endpoints so it can still be rendered as a description line
+// even though Immich has no map-route concept yet.
+type MapEntry struct {
+	Origin      LocationEntry
+	Destination LocationEntry
 }
 
 func (ge *googleEnrichments) LogValue() slog.Value {
 	if ge == nil {
 		return slog.Value{}
 	}
-	return slog.GroupValue(
-		slog.String("Text", ge.Text),
-		slog.Float64("Latitude", ge.Latitude),
-		slog.Float64("Longitude", ge.Longitude),
-	)
+	attrs := make([]slog.Attr, 0, len(*ge))
+	for i, e := range *ge {
+		switch {
+		case e.Narrative != nil:
+			attrs = append(attrs, slog.String(fmt.Sprintf("%d.narrative", i), e.Narrative.Text))
+		case e.Location != nil:
+			attrs = append(attrs, slog.String(fmt.Sprintf("%d.location", i), e.Location.Name))
+		case e.Map != nil:
+			attrs = append(attrs, slog.String(fmt.Sprintf("%d.map", i), e.Map.Origin.Name+" -> "+e.Map.Destination.Name))
+		}
+	}
+	return slog.GroupValue(attrs...)
 }
 
-func (ge *googleEnrichments) UnmarshalJSON(b []byte) error {
-	type googleEnrichment struct {
-		NarrativeEnrichment struct {
+// Description renders the enrichments as the multi-line text block used for
+// the Immich album description: one line per narrative text, location pin
+// or map leg, in their original order.
+func (ge googleEnrichments) Description() string {
+	var lines []string
+	for _, e := range ge {
+		switch {
+		case e.Narrative != nil && e.Narrative.Text != "":
+			lines = append(lines, e.Narrative.Text)
+		case e.Location != nil:
+			lines = append(lines, e.Location.line())
+		case e.Map != nil:
+			lines = append(lines, fmt.Sprintf("%s -> %s", e.Map.Origin.line(), e.Map.Destination.line()))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Locations returns every location pin carried by the enrichments, in
+// order, including the endpoints of any map legs, so all of them can be
+// retained as Immich album markers once that API exists.
+func (ge googleEnrichments) Locations() []LocationEntry {
+	var locations []LocationEntry
+	for _, e := range ge {
+		switch {
+		case e.Location != nil:
+			locations = append(locations, *e.Location)
+		case e.Map != nil:
+			locations = append(locations, e.Map.Origin, e.Map.Destination)
+		}
+	}
+	return locations
+}
+
+func (l LocationEntry) line() string {
+	if l.Description != "" {
+		return addString(l.Name, " - ", l.Description)
+	}
+	return l.Name
+}
+
+// MarshalJSON writes ge back out using the same narrativeEnrichment/
+// locationEnrichment/mapEnrichment shape UnmarshalJSON parses, so a sidecar
+// re-serialized after being read (rather than copied byte for byte) still
+// round-trips through Takeout-compatible JSON instead of leaking the Go
+// field names of enrichmentEntry.
+func (ge googleEnrichments) MarshalJSON() ([]byte, error) {
+	type rawLocation struct {
+		Name        string `json:"name"`
+		Description string `json:"description,omitempty"`
+		LatitudeE7  int    `json:"latitudeE7,omitempty"`
+		LongitudeE7 int    `json:"longitudeE7,omitempty"`
+	}
+	type rawEnrichment struct {
+		NarrativeEnrichment *struct {
 			Text string `json:"text"`
 		} `json:"narrativeEnrichment,omitempty"`
-		LocationEnrichment struct {
-			Location []struct {
-				Name        string `json:"name"`
-				Description string `json:"description"`
-				LatitudeE7  int    `json:"latitudeE7"`
-				LongitudeE7 int    `json:"longitudeE7"`
-			} `json:"location"`
+		LocationEnrichment *struct {
+			Location []rawLocation `json:"location"`
 		} `json:"locationEnrichment,omitempty"`
+		MapEnrichment *struct {
+			Origin      rawLocation `json:"origin"`
+			Destination rawLocation `json:"destination"`
+		} `json:"mapEnrichment,omitempty"`
 	}
 
-	var enrichments []googleEnrichment
+	fromLocation := func(l LocationEntry) rawLocation {
+		return rawLocation{
+			Name:        l.Name,
+			Description: l.Description,
+			LatitudeE7:  int(l.Lat * 10e6),
+			LongitudeE7: int(l.Lon * 10e6),
+		}
+	}
 
-	err := json.Unmarshal(b, &enrichments)
-	if err != nil {
+	enrichments := make([]rawEnrichment, 0, len(ge))
+	for _, e := range ge {
+		switch {
+		case e.Narrative != nil:
+			enrichments = append(enrichments, rawEnrichment{
+				NarrativeEnrichment: &struct {
+					Text string `json:"text"`
+				}{Text: e.Narrative.Text},
+			})
+		case e.Location != nil:
+			enrichments = append(enrichments, rawEnrichment{
+				LocationEnrichment: &struct {
+					Location []rawLocation `json:"location"`
+				}{Location: []rawLocation{fromLocation(*e.Location)}},
+			})
+		case e.Map != nil:
+			enrichments = append(enrichments, rawEnrichment{
+				MapEnrichment: &struct {
+					Origin      rawLocation `json:"origin"`
+					Destination rawLocation `json:"destination"`
+				}{Origin: fromLocation(e.Map.Origin), Destination: fromLocation(e.Map.Destination)},
+			})
+		}
+	}
+	return json.Marshal(enrichments)
+}
+
+func (ge *googleEnrichments) UnmarshalJSON(b []byte) error {
+	type rawLocation struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		LatitudeE7  int    `json:"latitudeE7"`
+		LongitudeE7 int    `json:"longitudeE7"`
+	}
+	type rawEnrichment struct {
+		NarrativeEnrichment struct {
+			Text string `json:"text"`
+		} `json:"narrativeEnrichment"`
+		LocationEnrichment struct {
+			Location []rawLocation `json:"location"`
+		} `json:"locationEnrichment"`
+		MapEnrichment struct {
+			Origin      rawLocation `json:"origin"`
+			Destination rawLocation `json:"destination"`
+		} `json:"mapEnrichment"`
+	}
+
+	var enrichments []rawEnrichment
+	if err := json.Unmarshal(b, &enrichments); err != nil {
 		return err
 	}
 
-	for _, e := range enrichments {
-		if e.NarrativeEnrichment.Text != "" {
-			ge.Text = addString(ge.Text, "\n", e.NarrativeEnrichment.Text)
+	toLocation := func(l rawLocation) LocationEntry {
+		return LocationEntry{
+			Name:        l.Name,
+			Description: l.Description,
+			Lat:         float64(l.LatitudeE7) / 10e6,
+			Lon:         float64(l.LongitudeE7) / 10e6,
 		}
-		if e.LocationEnrichment.Location != nil {
+	}
+
+	entries := make(googleEnrichments, 0, len(enrichments))
+	for _, e := range enrichments {
+		switch {
+		case e.NarrativeEnrichment.Text != "":
+			entries = append(entries, enrichmentEntry{Narrative: &NarrativeEntry{Text: e.NarrativeEnrichment.Text}})
+		case len(e.LocationEnrichment.Location) > 0:
 			for _, l := range e.LocationEnrichment.Location {
-				if l.Name != "" {
-					ge.Text = addString(ge.Text, "\n", l.Name)
-				}
-				if l.Description != "" {
-					ge.Text = addString(ge.Text, " - ", l.Description)
-				}
-				ge.Latitude = float64(l.LatitudeE7) / 10e6
-				ge.Longitude = float64(l.LongitudeE7) / 10e6
+				loc := toLocation(l)
+				entries = append(entries, enrichmentEntry{Location: &loc})
 			}
+		case e.MapEnrichment.Origin.Name != "" || e.MapEnrichment.Destination.Name != "":
+			entries = append(entries, enrichmentEntry{Map: &MapEntry{
+				Origin:      toLocation(e.MapEnrichment.Origin),
+				Destination: toLocation(e.MapEnrichment.Destination),
+			}})
 		}
 	}
-	return err
+
+	*ge = entries
+	return nil
 }
 
 func addString(s string, sep string, t string) string {
@@ -0,0 +1,110 @@
+package gp
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestGoogTimeObjectUnmarshal(t *testing.T) {
+	cases := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"object with string timestamp", `{"timestamp":"1609459200","formatted":"Jan 1, 2021"}`, "1609459200"},
+		{"object with numeric timestamp", `{"timestamp":1609459200}`, "1609459200"},
+		{"bare string", `"1609459200"`, "1609459200"},
+		{"bare number", `1609459200`, "1609459200"},
+		{"RFC3339 string", `"2021-01-01T00:00:00Z"`, "2021-01-01T00:00:00Z"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var gt googTimeObject
+			if err := json.Unmarshal([]byte(c.data), &gt); err != nil {
+				t.Fatalf("Unmarshal(%s): %v", c.data, err)
+			}
+			if gt.Timestamp != c.want {
+				t.Errorf("Timestamp = %q, want %q", gt.Timestamp, c.want)
+			}
+		})
+	}
+}
+
+func TestGoogTimeObjectTime(t *testing.T) {
+	want := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	epoch := googTimeObject{Timestamp: "1609459200"}
+	if got := epoch.Time().UTC(); !got.Equal(want) {
+		t.Errorf("epoch Time() = %v, want %v", got, want)
+	}
+
+	rfc := googTimeObject{Timestamp: "2021-01-01T00:00:00Z"}
+	if got := rfc.Time().UTC(); !got.Equal(want) {
+		t.Errorf("RFC3339 Time() = %v, want %v", got, want)
+	}
+
+	if got := (googTimeObject{}).Time(); !got.IsZero() {
+		t.Errorf("empty Time() = %v, want zero", got)
+	}
+}
+
+func TestGoogIntStringUnmarshal(t *testing.T) {
+	var quoted googIntString
+	if err := json.Unmarshal([]byte(`"42"`), &quoted); err != nil {
+		t.Fatalf("Unmarshal quoted: %v", err)
+	}
+	if quoted != 42 {
+		t.Errorf("quoted = %d, want 42", quoted)
+	}
+
+	var bare googIntString
+	if err := json.Unmarshal([]byte(`42`), &bare); err != nil {
+		t.Fatalf("Unmarshal bare: %v", err)
+	}
+	if bare != 42 {
+		t.Errorf("bare = %d, want 42", bare)
+	}
+}
+
+func TestGoogleEnrichmentsDescriptionAndLocations(t *testing.T) {
+	data := []byte(`[
+		{"narrativeEnrichment":{"text":"A great trip"}},
+		{"locationEnrichment":{"location":[{"name":"Paris","description":"Capital","latitudeE7":488566000,"longitudeE7":23522000}]}},
+		{"mapEnrichment":{"origin":{"name":"Paris"},"destination":{"name":"Berlin"}}}
+	]`)
+
+	var ge googleEnrichments
+	if err := json.Unmarshal(data, &ge); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(ge) != 3 {
+		t.Fatalf("len(ge) = %d, want 3", len(ge))
+	}
+
+	wantDescription := "A great trip\nParis - Capital\nParis -> Berlin"
+	if got := ge.Description(); got != wantDescription {
+		t.Errorf("Description() = %q, want %q", got, wantDescription)
+	}
+
+	locations := ge.Locations()
+	if len(locations) != 3 {
+		t.Fatalf("len(Locations()) = %d, want 3", len(locations))
+	}
+	if locations[0].Name != "Paris" || locations[1].Name != "Paris" || locations[2].Name != "Berlin" {
+		t.Errorf("Locations() = %+v, want Paris, Paris, Berlin", locations)
+	}
+
+	marshaled, err := json.Marshal(ge)
+	if err != nil {
+		t.Fatalf("MarshalJSON: %v", err)
+	}
+	var roundTripped googleEnrichments
+	if err := json.Unmarshal(marshaled, &roundTripped); err != nil {
+		t.Fatalf("Unmarshal(MarshalJSON output): %v", err)
+	}
+	if got := roundTripped.Description(); got != wantDescription {
+		t.Errorf("round-tripped Description() = %q, want %q", got, wantDescription)
+	}
+}
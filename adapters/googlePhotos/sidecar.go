@@ -0,0 +1,262 @@
+package gp
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/spf13/pflag"
+)
+
+// takeoutNameLimit is the basename length (without extension) at which
+// Google Takeout starts truncating the JSON sidecar's name.
+const takeoutNameLimit = 46
+
+// SidecarCandidate is one *.json file found alongside a batch of media
+// files, offered to a SidecarMatcher for pairing.
+type SidecarCandidate struct {
+	Name     string // base name of the sidecar file, without directory
+	Metadata *GoogleMetaData
+}
+
+// mediaName returns the best guess at the media file this sidecar
+// describes: its own name with the ".json" suffix stripped, which is what
+// Takeout names the sidecar after in the first place. It's only a guess -
+// truncation or a disambiguator may mean no file by that exact name exists -
+// but it's the only candidate the hash matcher has to probe, since the
+// whole point of hashing is to pair sidecars whose name doesn't otherwise
+// resolve to a real media file.
+func (c SidecarCandidate) mediaName() string {
+	return strings.TrimSuffix(c.Name, ".json")
+}
+
+// SidecarMatcher decides whether a media file name is described by one of
+// a set of sidecar candidates. Matchers run in order, from the cheapest and
+// most precise to the most expensive and most permissive, so that a
+// strategy further down the list only has to consider what the earlier
+// ones left unmatched.
+type SidecarMatcher interface {
+	// Name identifies the strategy in --sidecar-match and in audit log
+	// entries.
+	Name() string
+	// Match returns the candidate that pairs with mediaName, if any.
+	Match(mediaName string, candidates []SidecarCandidate) (*SidecarCandidate, bool)
+}
+
+// ParseSidecarMatchers builds the matcher chain for a --sidecar-match
+// value, a comma-separated list such as "exact,truncated,title,hash". probe
+// is only needed when "hash" is requested; it may be nil otherwise.
+func ParseSidecarMatchers(modes string, probe MediaProbe) ([]SidecarMatcher, error) {
+	if modes == "" {
+		modes = "exact,truncated"
+	}
+
+	var matchers []SidecarMatcher
+	for _, mode := range strings.Split(modes, ",") {
+		switch strings.TrimSpace(mode) {
+		case "exact":
+			matchers = append(matchers, exactMatcher{})
+		case "truncated":
+			matchers = append(matchers, truncatedMatcher{})
+		case "title":
+			matchers = append(matchers, titleMatcher{})
+		case "hash":
+			matchers = append(matchers, hashMatcher{Probe: probe})
+		default:
+			return nil, fmt.Errorf("unknown --sidecar-match mode: %q", mode)
+		}
+	}
+	return matchers, nil
+}
+
+// AddSidecarMatchFlags registers the --sidecar-match flag shared by the
+// Takeout-based archive subcommands.
+func AddSidecarMatchFlags(flags *pflag.FlagSet, modes *string) {
+	flags.StringVar(modes, "sidecar-match", "exact,truncated",
+		"Comma separated list of strategies used to pair a media file with its JSON sidecar: exact,truncated,title,hash "+
+			"(hash needs a media probe, which the archive commands do not supply, so it pairs nothing there)")
+}
+
+// MatchSidecar runs matchers in order against *candidates until one of them
+// pairs mediaName with a sidecar, recording the outcome either way so users
+// can audit why two files were, or weren't, associated. The matched
+// candidate is removed from *candidates so a caller pairing several media
+// files against the same pool can't associate one sidecar with two of them.
+func MatchSidecar(jnl *fileevent.Recorder, mediaName string, candidates *[]SidecarCandidate, matchers []SidecarMatcher) (*SidecarCandidate, bool) {
+	for _, m := range matchers {
+		candidate, ok := m.Match(mediaName, *candidates)
+		if !ok {
+			continue
+		}
+		matched := *candidate
+		for i := range *candidates {
+			if (*candidates)[i].Name == matched.Name {
+				*candidates = append((*candidates)[:i], (*candidates)[i+1:]...)
+				break
+			}
+		}
+		jnl.Log().Info("sidecar matched", "media", mediaName, "sidecar", matched.Name, "strategy", m.Name())
+		return &matched, true
+	}
+	jnl.Log().Warn("sidecar not matched", "media", mediaName, "candidates", len(*candidates))
+	return nil, false
+}
+
+// exactMatcher pairs "name.ext" with "name.ext.json", the common case.
+type exactMatcher struct{}
+
+func (exactMatcher) Name() string { return "exact" }
+
+func (exactMatcher) Match(mediaName string, candidates []SidecarCandidate) (*SidecarCandidate, bool) {
+	want := mediaName + ".json"
+	for i, c := range candidates {
+		if c.Name == want {
+			return &candidates[i], true
+		}
+	}
+	return nil, false
+}
+
+// truncatedMatcher reproduces Takeout's basename truncation: the sidecar's
+// basename (without ".json") is cut down to takeoutNameLimit runes, and a
+// "(n)" disambiguator may have been appended to either name.
+type truncatedMatcher struct{}
+
+func (truncatedMatcher) Name() string { return "truncated" }
+
+func (truncatedMatcher) Match(mediaName string, candidates []SidecarCandidate) (*SidecarCandidate, bool) {
+	mediaBase := stripDisambiguator(mediaName)
+
+	for i, c := range candidates {
+		sidecarBase := strings.TrimSuffix(c.Name, ".json")
+		sidecarBase = stripDisambiguator(sidecarBase)
+
+		truncated := truncateRunes(mediaBase, takeoutNameLimit)
+		if sidecarBase == truncated || sidecarBase == mediaBase {
+			return &candidates[i], true
+		}
+	}
+	return nil, false
+}
+
+// titleMatcher pairs a sidecar whose GoogleMetaData.Title, once normalized,
+// equals the media file name. Takeout sometimes ships sidecars whose
+// filename bears no relation to the media file, but whose title field
+// still names it.
+type titleMatcher struct{}
+
+func (titleMatcher) Name() string { return "title" }
+
+func (titleMatcher) Match(mediaName string, candidates []SidecarCandidate) (*SidecarCandidate, bool) {
+	want := normalizeTitle(mediaName)
+	for i, c := range candidates {
+		if c.Metadata == nil {
+			continue
+		}
+		if normalizeTitle(c.Metadata.Title) == want {
+			return &candidates[i], true
+		}
+	}
+	return nil, false
+}
+
+// hashMatcher is the final tiebreaker for whatever exact, truncated and
+// title left unmatched: among the remaining candidates that describe an
+// asset (a PhotoTakenTime or CreationTime is set) and whose media file has
+// the same size as the media file, it picks the one with the closest
+// perceptual hash. The candidates' timestamps are not compared with the
+// media file.
+//
+// It relies on a MediaProbe supplied by the caller, since matching needs to
+// read both the media file and any surviving candidate's own media file to
+// compute a hash - something this package, which only deals with JSON
+// sidecars, has no access to on its own.
+type hashMatcher struct {
+	Probe MediaProbe
+}
+
+// MediaProbe exposes just enough about a file on disk for the hash
+// strategy to compare it against sidecar candidates.
+type MediaProbe interface {
+	// Size returns the size in bytes of the named media file.
+	Size(name string) (int64, error)
+	// PerceptualHash returns a hash describing the visual content of the
+	// named media file, used to break remaining ties.
+	PerceptualHash(name string) (uint64, error)
+}
+
+func (hashMatcher) Name() string { return "hash" }
+
+func (h hashMatcher) Match(mediaName string, candidates []SidecarCandidate) (*SidecarCandidate, bool) {
+	if h.Probe == nil || len(candidates) == 0 {
+		return nil, false
+	}
+
+	mediaSize, err := h.Probe.Size(mediaName)
+	if err != nil {
+		return nil, false
+	}
+	mediaHash, err := h.Probe.PerceptualHash(mediaName)
+	if err != nil {
+		return nil, false
+	}
+
+	var best *SidecarCandidate
+	bestDistance := -1
+	for i, c := range candidates {
+		if c.Metadata == nil || !c.Metadata.isAsset() {
+			continue
+		}
+		candidateMedia := c.mediaName()
+		size, err := h.Probe.Size(candidateMedia)
+		if err != nil || size != mediaSize {
+			continue
+		}
+		hash, err := h.Probe.PerceptualHash(candidateMedia)
+		if err != nil {
+			continue
+		}
+		distance := hammingDistance(mediaHash, hash)
+		if best == nil || distance < bestDistance {
+			best, bestDistance = &candidates[i], distance
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best, true
+}
+
+func hammingDistance(a, b uint64) int {
+	x := a ^ b
+	count := 0
+	for x != 0 {
+		count++
+		x &= x - 1
+	}
+	return count
+}
+
+// stripDisambiguator removes a trailing " (1)", "(2)", ... Takeout adds
+// when several files would otherwise share the same sidecar name.
+func stripDisambiguator(name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	if i := strings.LastIndex(base, "("); i > 0 && strings.HasSuffix(base, ")") {
+		base = strings.TrimRight(base[:i], " ")
+	}
+	return base + ext
+}
+
+func truncateRunes(s string, limit int) string {
+	r := []rune(s)
+	if len(r) <= limit {
+		return s
+	}
+	return string(r[:limit])
+}
+
+func normalizeTitle(s string) string {
+	return strings.ToLower(strings.TrimSpace(s))
+}
@@ -0,0 +1,112 @@
+package gp
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+func newTestRecorder() *fileevent.Recorder {
+	return fileevent.NewRecorder(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestTruncatedMatcher(t *testing.T) {
+	long := "this-name-is-way-longer-than-the-takeout-truncation-limit-of-46-runes.jpg"
+	candidates := []SidecarCandidate{
+		{Name: truncateRunes(stripDisambiguator(long), takeoutNameLimit) + ".json"},
+	}
+
+	m := truncatedMatcher{}
+	got, ok := m.Match(long, candidates)
+	if !ok {
+		t.Fatalf("Match(%q) = false, want true", long)
+	}
+	if got.Name != candidates[0].Name {
+		t.Errorf("Match(%q) = %q, want %q", long, got.Name, candidates[0].Name)
+	}
+}
+
+func TestTruncatedMatcherDisambiguator(t *testing.T) {
+	candidates := []SidecarCandidate{
+		{Name: "photo.jpg.json"},
+	}
+	got, ok := truncatedMatcher{}.Match("photo(1).jpg", candidates)
+	if !ok {
+		t.Fatalf("Match with disambiguator = false, want true")
+	}
+	if got.Name != "photo.jpg.json" {
+		t.Errorf("Match = %q, want photo.jpg.json", got.Name)
+	}
+}
+
+func TestSidecarCandidateMediaName(t *testing.T) {
+	c := SidecarCandidate{Name: "IMG_0001.jpg.json"}
+	if got := c.mediaName(); got != "IMG_0001.jpg" {
+		t.Errorf("mediaName() = %q, want IMG_0001.jpg", got)
+	}
+}
+
+type fakeProbe struct {
+	sizes  map[string]int64
+	hashes map[string]uint64
+}
+
+func (p fakeProbe) Size(name string) (int64, error)            { return p.sizes[name], nil }
+func (p fakeProbe) PerceptualHash(name string) (uint64, error) { return p.hashes[name], nil }
+
+func TestHashMatcherPicksClosestHash(t *testing.T) {
+	probe := fakeProbe{
+		sizes: map[string]int64{
+			"IMG_0001.jpg": 1000,
+			"a.jpg":        1000,
+			"b.jpg":        1000,
+		},
+		hashes: map[string]uint64{
+			"IMG_0001.jpg": 0b0000,
+			"a.jpg":        0b0001, // distance 1
+			"b.jpg":        0b1111, // distance 4
+		},
+	}
+
+	candidates := []SidecarCandidate{
+		{Name: "a.jpg.json", Metadata: &GoogleMetaData{PhotoTakenTime: &googTimeObject{Timestamp: "1"}}},
+		{Name: "b.jpg.json", Metadata: &GoogleMetaData{PhotoTakenTime: &googTimeObject{Timestamp: "1"}}},
+	}
+	// hashMatcher probes mediaName(), the candidate's sidecar name with
+	// ".json" stripped, so "a.jpg.json"/"b.jpg.json" resolve to the
+	// "a.jpg"/"b.jpg" entries already registered in probe above.
+
+	m := hashMatcher{Probe: probe}
+	got, ok := m.Match("IMG_0001.jpg", candidates)
+	if !ok {
+		t.Fatalf("Match() = false, want true")
+	}
+	if got.Name != "a.jpg.json" {
+		t.Errorf("Match() = %q, want a.jpg.json (closer hash)", got.Name)
+	}
+}
+
+func TestMatchSidecarConsumesCandidate(t *testing.T) {
+	candidates := []SidecarCandidate{
+		{Name: "IMG_0001.jpg.json"},
+	}
+	matchers := []SidecarMatcher{exactMatcher{}}
+	jnl := newTestRecorder()
+
+	first, ok := MatchSidecar(jnl, "IMG_0001.jpg", &candidates, matchers)
+	if !ok || first.Name != "IMG_0001.jpg.json" {
+		t.Fatalf("first MatchSidecar = %+v, %v, want IMG_0001.jpg.json, true", first, ok)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("candidates after first match = %d, want 0 (consumed)", len(candidates))
+	}
+
+	// A second, unrelated media file must not be paired with the same
+	// sidecar again now that it has been consumed.
+	_, ok = MatchSidecar(jnl, "IMG_0002.jpg", &candidates, matchers)
+	if ok {
+		t.Fatalf("second MatchSidecar matched an already-consumed sidecar")
+	}
+}
@@ -0,0 +1,59 @@
+package googlePhotosAPI
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+var oauth2Endpoint = google.Endpoint
+
+// loadCachedToken reads a previously obtained OAuth2 token from disk.
+func loadCachedToken(path string) (*oauth2.Token, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var token oauth2.Token
+	if err := json.Unmarshal(b, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// saveCachedToken persists an OAuth2 token so the next run can skip the
+// interactive consent flow.
+func saveCachedToken(path string, token *oauth2.Token) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	b, err := json.Marshal(token)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o600)
+}
+
+// authenticate runs the OAuth2 authorization-code flow out of band: the user
+// opens the URL, grants access, and pastes back the resulting code.
+func authenticate(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	authURL := config.AuthCodeURL("state", oauth2.AccessTypeOffline)
+	fmt.Printf("Open the following URL in a browser, grant access, then paste the code below:\n%s\n", authURL)
+
+	var code string
+	if _, err := fmt.Scanln(&code); err != nil {
+		return nil, fmt.Errorf("reading authorization code: %w", err)
+	}
+	return config.Exchange(ctx, code)
+}
+
+func newJSONReader(b []byte) io.Reader {
+	return bytes.NewReader(b)
+}
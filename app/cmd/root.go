@@ -0,0 +1,29 @@
+// Package cmd assembles the immich-go root command from the command trees
+// implemented by its subpackages.
+package cmd
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/commands/application"
+	"github.com/simulot/immich-go/commands/archive"
+	"github.com/simulot/immich-go/commands/upload"
+	"github.com/spf13/cobra"
+)
+
+// RootImmichGoCommand builds the immich-go root command, with every
+// top-level command tree (archive, upload, ...) attached, along with the
+// Application shared across them.
+func RootImmichGoCommand(ctx context.Context) (*cobra.Command, *application.Application) {
+	cmd := &cobra.Command{
+		Use:   "immich-go",
+		Short: "Upload photos to Immich or archive them to another file system",
+	}
+
+	app := application.New(ctx, cmd)
+
+	cmd.AddCommand(archive.NewArchiveCommand(ctx, app))
+	cmd.AddCommand(upload.NewUploadCommand(ctx, app))
+
+	return cmd, app
+}
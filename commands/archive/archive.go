@@ -3,13 +3,19 @@ package archive
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/simulot/immich-go/adapters/folder"
+	gp "github.com/simulot/immich-go/adapters/googlePhotos"
+	"github.com/simulot/immich-go/adapters/googlePhotosAPI"
 	"github.com/simulot/immich-go/commands/application"
+	"github.com/simulot/immich-go/internal/archivemanifest"
 	"github.com/simulot/immich-go/internal/fileevent"
-	"github.com/simulot/immich-go/internal/filenames"
 	"github.com/simulot/immich-go/internal/fshelper"
 	"github.com/simulot/immich-go/internal/fshelper/osfs"
 	"github.com/spf13/cobra"
@@ -30,10 +36,118 @@ func NewArchiveCommand(ctx context.Context, app *application.Application) *cobra
 	_ = cmd.MarkPersistentFlagRequired("write-to-folder")
 
 	cmd.AddCommand(NewImportFromFolderCommand(ctx, app, options))
+	cmd.AddCommand(NewImportFromGooglePhotosCommand(ctx, app, options))
+	cmd.AddCommand(NewImportFromGooglePhotosAPICommand(ctx, app, options))
+	cmd.AddCommand(NewArchiveResumeCommand(ctx, app, options))
+	cmd.AddCommand(NewArchiveVerifyCommand(ctx, app, options))
 
 	return cmd
 }
 
+// NewImportFromGooglePhotosAPICommand archives a live Google Photos account
+// straight from the Library API, without requiring a Takeout export.
+func NewImportFromGooglePhotosAPICommand(ctx context.Context, app *application.Application, archOptions *ArchiveOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "from-google-photos-api",
+		Short: "Archive photos straight from the Google Photos Library API",
+	}
+
+	options := &googlePhotosAPI.Options{}
+	options.AddFromGooglePhotosAPIFlags(cmd.Flags())
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		ctx := cmd.Context()
+		log := app.Log()
+		if app.Jnl() == nil {
+			app.SetJnl(fileevent.NewRecorder(app.Log().Logger))
+		}
+		if err := options.Resolve(); err != nil {
+			return err
+		}
+
+		p, err := cmd.Flags().GetString("write-to-folder")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			return err
+		}
+
+		client, err := googlePhotosAPI.NewClient(ctx, log.Logger, *options)
+		if err != nil {
+			return err
+		}
+
+		assets, err := client.Browse(ctx, options.Album)
+		if err != nil {
+			return err
+		}
+
+		manifest, err := archivemanifest.Load(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivemanifest.FileName, err)
+		}
+
+		runErr := archiveGooglePhotosAPIAssets(ctx, app.Jnl(), osfs.DirFS(p), p, assets, manifest)
+		if err := manifest.Save(); err != nil {
+			log.Error("writing " + archivemanifest.FileName + ": " + err.Error())
+		}
+		return runErr
+	}
+	return cmd
+}
+
+// archiveGooglePhotosAPIAssets writes every asset coming out of assetChan to
+// destDir, recording each attempt in manifest so a later run can skip assets
+// already archived from this account. It writes directly to the file system
+// rather than going through folder.NewLocalAssetWriter, since a remote
+// source has no local file identity for that writer's collision handling to
+// key off beyond the filename the API happens to report.
+func archiveGooglePhotosAPIAssets(ctx context.Context, jnl *fileevent.Recorder, destFS fs.FS, destDir string, assetChan chan *googlePhotosAPI.Asset, manifest *archivemanifest.Manifest) error {
+	for asset := range assetChan {
+		key := asset.Key
+		if key == "" {
+			key = asset.Metadata.FileName
+		}
+		if manifest.Done(key) {
+			jnl.Log().Info("already archived, skipping", "key", key, "file", asset.Metadata.FileName)
+			continue
+		}
+
+		entry := archivemanifest.Entry{Key: key, Filename: asset.Metadata.FileName}
+		if err := archiveGooglePhotosAPIAsset(ctx, destDir, asset, &entry); err != nil {
+			entry.Status = archivemanifest.StatusFailed
+			entry.Error = err.Error()
+			jnl.Log().Error("archiving asset: " + err.Error())
+		} else {
+			entry.Status = archivemanifest.StatusOK
+			if hash, err := archivemanifest.HashFile(destFS, entry.Filename); err == nil {
+				entry.SHA256 = hash
+			}
+			jnl.Log().Info("archived", "key", key, "file", entry.Filename)
+		}
+		manifest.Put(entry)
+	}
+	return nil
+}
+
+func archiveGooglePhotosAPIAsset(ctx context.Context, destDir string, asset *googlePhotosAPI.Asset, entry *archivemanifest.Entry) error {
+	r, err := asset.Open(ctx)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	f, err := os.Create(filepath.Join(destDir, asset.Metadata.FileName))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, r)
+	return err
+}
+
 func NewImportFromFolderCommand(ctx context.Context, app *application.Application, archOptions *ArchiveOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "from-folder",
@@ -45,7 +159,6 @@ func NewImportFromFolderCommand(ctx context.Context, app *application.Applicatio
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
 		// ready to run
-		ctx := cmd.Context()
 		log := app.Log()
 		if app.Jnl() == nil {
 			app.SetJnl(fileevent.NewRecorder(app.Log().Logger))
@@ -60,8 +173,6 @@ func NewImportFromFolderCommand(ctx context.Context, app *application.Applicatio
 			return err
 		}
 
-		destFS := osfs.DirFS(p)
-
 		// parse arguments
 		fsyss, err := fshelper.ParsePath(args)
 		if err != nil {
@@ -71,17 +182,134 @@ func NewImportFromFolderCommand(ctx context.Context, app *application.Applicatio
 			log.Message("No file found matching the pattern: %s", strings.Join(args, ","))
 			return errors.New("No file found matching the pattern: " + strings.Join(args, ","))
 		}
-		options.InfoCollector = filenames.NewInfoCollector(app.GetTZ(), options.SupportedMedia)
-		source, err := folder.NewLocalFiles(ctx, app.Jnl(), options, fsyss...)
+
+		manifest, err := archivemanifest.Load(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivemanifest.FileName, err)
+		}
+
+		runErr := archiveMediaFiles(app.Jnl(), fsyss, p, manifest, nil, nil)
+		if err := manifest.Save(); err != nil {
+			log.Error("writing " + archivemanifest.FileName + ": " + err.Error())
+		}
+		return runErr
+	}
+	return cmd
+}
+
+// NewArchiveResumeCommand re-reads the manifest left by a previous archive
+// run, re-opens the same sources, and retries just the entries that were
+// left in StatusFailed, so a large (100k+ asset) archive can be restarted
+// after a crash without redoing the assets that already succeeded.
+//
+// Sidecars are paired again only when --sidecar-match is given, so failed
+// entries keyed by a sidecar (as written by `from-google-photos`) are retried
+// only then. The sources it re-opens are file system paths, so entries
+// written by `from-google-photos-api` are not retried.
+func NewArchiveResumeCommand(ctx context.Context, app *application.Application, archOptions *ArchiveOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resume",
+		Short: "Retry the assets that failed during a previous archive run",
+	}
+
+	var sidecarMode string
+	gp.AddSidecarMatchFlags(cmd.Flags(), &sidecarMode)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		log := app.Log()
+		if app.Jnl() == nil {
+			app.SetJnl(fileevent.NewRecorder(app.Log().Logger))
+		}
+		p, err := cmd.Flags().GetString("write-to-folder")
+		if err != nil {
+			return err
+		}
+
+		manifest, err := archivemanifest.Load(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivemanifest.FileName, err)
+		}
+
+		failed := manifest.Failed()
+		if len(failed) == 0 {
+			log.Message("No failed entries to resume.")
+			return nil
+		}
+
+		fsyss, err := fshelper.ParsePath(args)
+		if err != nil {
+			return err
+		}
+		if len(fsyss) == 0 {
+			log.Message("No file found matching the pattern: %s", strings.Join(args, ","))
+			return errors.New("No file found matching the pattern: " + strings.Join(args, ","))
+		}
+
+		retryOnly := make(map[string]bool, len(failed))
+		for _, e := range failed {
+			retryOnly[e.Key] = true
+		}
+
+		var pairs pairing
+		if cmd.Flags().Changed("sidecar-match") {
+			matchers, err := gp.ParseSidecarMatchers(sidecarMode, nil)
+			if err != nil {
+				return err
+			}
+			pairs, err = pairSidecars(app.Jnl(), fsyss, matchers)
+			if err != nil {
+				return err
+			}
+		}
+
+		runErr := archiveMediaFiles(app.Jnl(), fsyss, p, manifest, pairs, retryOnly)
+		if err := manifest.Save(); err != nil {
+			log.Error("writing " + archivemanifest.FileName + ": " + err.Error())
+		}
+		return runErr
+	}
+	return cmd
+}
+
+// NewArchiveVerifyCommand rehashes every successfully archived file and
+// reports any that have drifted or gone missing since they were written.
+func NewArchiveVerifyCommand(ctx context.Context, app *application.Application, archOptions *ArchiveOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Rehash the archive destination to detect drift from the manifest",
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		log := app.Log()
+		p, err := cmd.Flags().GetString("write-to-folder")
 		if err != nil {
 			return err
 		}
+		destFS := osfs.DirFS(p)
+
+		manifest, err := archivemanifest.Load(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivemanifest.FileName, err)
+		}
 
-		dest, err := folder.NewLocalAssetWriter(destFS, ".")
+		results, err := archivemanifest.Verify(destFS, manifest)
 		if err != nil {
 			return err
 		}
-		return run(ctx, app.Jnl(), app, source, dest)
+
+		drifted := 0
+		for _, r := range results {
+			switch {
+			case r.Error != "":
+				drifted++
+				log.Message("missing or unreadable: %s: %s", r.Entry.Filename, r.Error)
+			case r.Drifted:
+				drifted++
+				log.Message("drifted: %s", r.Entry.Filename)
+			}
+		}
+		log.Message("%d/%d entries verified, %d drifted", len(results)-drifted, len(results), drifted)
+		return nil
 	}
 	return cmd
 }
@@ -0,0 +1,146 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/simulot/immich-go/adapters/folder"
+	gp "github.com/simulot/immich-go/adapters/googlePhotos"
+	"github.com/simulot/immich-go/commands/application"
+	"github.com/simulot/immich-go/internal/archivemanifest"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/simulot/immich-go/internal/fshelper"
+	"github.com/spf13/cobra"
+)
+
+// NewImportFromGooglePhotosCommand archives a Google Takeout export,
+// pairing each media file with its JSON sidecar and copying both into the
+// archive, recording every attempt in the manifest so a second run skips
+// whatever already succeeded.
+func NewImportFromGooglePhotosCommand(ctx context.Context, app *application.Application, archOptions *ArchiveOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "from-google-photos",
+		Short: "Archive a Google Takeout export",
+	}
+
+	options := &folder.ImportFolderOptions{}
+	options.AddFromFolderFlags(cmd)
+
+	var sidecarMode string
+	gp.AddSidecarMatchFlags(cmd.Flags(), &sidecarMode)
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		log := app.Log()
+		if app.Jnl() == nil {
+			app.SetJnl(fileevent.NewRecorder(app.Log().Logger))
+		}
+
+		p, err := cmd.Flags().GetString("write-to-folder")
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			return err
+		}
+
+		fsyss, err := fshelper.ParsePath(args)
+		if err != nil {
+			return err
+		}
+		if len(fsyss) == 0 {
+			log.Message("No file found matching the pattern: %s", strings.Join(args, ","))
+			return errors.New("No file found matching the pattern: " + strings.Join(args, ","))
+		}
+
+		matchers, err := gp.ParseSidecarMatchers(sidecarMode, nil)
+		if err != nil {
+			return err
+		}
+		pairs, err := pairSidecars(app.Jnl(), fsyss, matchers)
+		if err != nil {
+			return err
+		}
+
+		manifest, err := archivemanifest.Load(p)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", archivemanifest.FileName, err)
+		}
+
+		runErr := archiveMediaFiles(app.Jnl(), fsyss, p, manifest, pairs, nil)
+		if err := manifest.Save(); err != nil {
+			log.Error("writing " + archivemanifest.FileName + ": " + err.Error())
+		}
+		return runErr
+	}
+	return cmd
+}
+
+// pairSidecars walks each source file system, grouping files by directory,
+// and runs the sidecar matcher chain for every media file against the JSON
+// sidecars found next to it. It returns the resulting pairing so the caller
+// can key manifest entries on the sidecar's title/timestamp and copy the
+// matched sidecar alongside its media file, instead of the pairing decision
+// being discarded after it's logged.
+func pairSidecars(jnl *fileevent.Recorder, fsyss []fs.FS, matchers []gp.SidecarMatcher) (pairing, error) {
+	pairs := pairing{}
+
+	for _, fsys := range fsyss {
+		candidatesByDir := map[string][]gp.SidecarCandidate{}
+		mediaByDir := map[string][]string{}
+
+		err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			dir := path.Dir(p)
+			name := path.Base(p)
+			if strings.EqualFold(path.Ext(name), ".json") {
+				md, err := readGoogleMetaData(fsys, p)
+				if err != nil {
+					jnl.Log().Warn("unreadable sidecar", "file", p, "error", err.Error())
+					return nil
+				}
+				candidatesByDir[dir] = append(candidatesByDir[dir], gp.SidecarCandidate{Name: name, Metadata: md})
+				return nil
+			}
+			mediaByDir[dir] = append(mediaByDir[dir], name)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for dir, medias := range mediaByDir {
+			candidates := candidatesByDir[dir]
+			for _, media := range medias {
+				candidate, ok := gp.MatchSidecar(jnl, media, &candidates, matchers)
+				if !ok {
+					continue
+				}
+				pairs[path.Join(dir, media)] = candidate
+			}
+		}
+	}
+	return pairs, nil
+}
+
+func readGoogleMetaData(fsys fs.FS, path string) (*gp.GoogleMetaData, error) {
+	b, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	var md gp.GoogleMetaData
+	if err := json.Unmarshal(b, &md); err != nil {
+		return nil, err
+	}
+	return &md, nil
+}
@@ -0,0 +1,120 @@
+package archive
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	gp "github.com/simulot/immich-go/adapters/googlePhotos"
+	"github.com/simulot/immich-go/internal/archivemanifest"
+	"github.com/simulot/immich-go/internal/fileevent"
+)
+
+// pairing maps a media file's path within its source file system (as
+// produced by fs.WalkDir, which always uses "/" regardless of OS) to the
+// sidecar candidate pairSidecars matched it with. A nil pairing means the
+// source has no sidecars, as with `archive from-folder`.
+type pairing map[string]*gp.SidecarCandidate
+
+// archiveMediaFiles walks every source file system, copies each media file
+// (and, when paired, its sidecar) into destDir, and records the outcome of
+// each attempt in manifest so a later run skips what already succeeded.
+// When retryOnly is non-nil, only keys present in it are considered, so
+// `archive resume` can target just the entries a previous run failed.
+//
+// It writes straight to the file system rather than going through
+// folder.NewLocalAssetWriter: that writer has no way to key its own
+// collision handling off a Google Photos sidecar's title/timestamp, which
+// is exactly the identity this manifest needs to track.
+func archiveMediaFiles(jnl *fileevent.Recorder, fsyss []fs.FS, destDir string, manifest *archivemanifest.Manifest, pairs pairing, retryOnly map[string]bool) error {
+	for _, fsys := range fsyss {
+		err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || strings.EqualFold(path.Ext(p), ".json") {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				jnl.Log().Error("stat " + p + ": " + err.Error())
+				return nil
+			}
+
+			var sidecar *gp.SidecarCandidate
+			if pairs != nil {
+				sidecar = pairs[p]
+			}
+
+			key := p
+			if sidecar != nil && sidecar.Metadata != nil {
+				key = sidecar.Metadata.Key()
+			}
+
+			if retryOnly != nil && !retryOnly[key] {
+				return nil
+			}
+			if manifest.Processed(key, info.Size(), info.ModTime()) {
+				jnl.Log().Info("already archived, skipping", "key", key, "file", p)
+				return nil
+			}
+
+			entry := archivemanifest.Entry{
+				Key:           key,
+				SourceSize:    info.Size(),
+				SourceModTime: info.ModTime(),
+				Filename:      path.Base(p),
+			}
+			if sidecar != nil {
+				entry.Sidecar = sidecar.Name
+			}
+
+			if err := copySourceFile(fsys, p, destDir, entry.Filename); err != nil {
+				entry.Status = archivemanifest.StatusFailed
+				entry.Error = err.Error()
+				jnl.Log().Error("archiving " + p + ": " + err.Error())
+				manifest.Put(entry)
+				return nil
+			}
+			if sidecar != nil {
+				sidecarPath := path.Join(path.Dir(p), sidecar.Name)
+				if err := copySourceFile(fsys, sidecarPath, destDir, sidecar.Name); err != nil {
+					jnl.Log().Warn("archiving sidecar", "file", sidecarPath, "error", err.Error())
+				}
+			}
+
+			if hash, err := archivemanifest.HashFile(os.DirFS(destDir), entry.Filename); err == nil {
+				entry.SHA256 = hash
+			}
+			entry.Status = archivemanifest.StatusOK
+			jnl.Log().Info("archived", "key", key, "file", entry.Filename)
+			manifest.Put(entry)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copySourceFile(fsys fs.FS, srcPath, destDir, destName string) error {
+	src, err := fsys.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.Create(filepath.Join(destDir, destName))
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
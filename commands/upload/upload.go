@@ -0,0 +1,80 @@
+// Package upload implements the `upload` command tree, which pushes assets
+// from a live Google Photos account straight to an Immich server.
+package upload
+
+import (
+	"context"
+
+	"github.com/simulot/immich-go/adapters/googlePhotosAPI"
+	"github.com/simulot/immich-go/commands/application"
+	"github.com/simulot/immich-go/internal/fileevent"
+	"github.com/spf13/cobra"
+)
+
+// NewUploadCommand returns the `upload` command and its subcommands.
+func NewUploadCommand(ctx context.Context, app *application.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "upload",
+		Short: "Upload photos to an Immich server",
+	}
+
+	cmd.AddCommand(NewImportFromGooglePhotosAPICommand(ctx, app))
+
+	return cmd
+}
+
+// NewImportFromGooglePhotosAPICommand uploads a live Google Photos account
+// straight from the Library API, without requiring a Takeout export.
+func NewImportFromGooglePhotosAPICommand(ctx context.Context, app *application.Application) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "from-google-photos-api",
+		Short: "Upload photos straight from the Google Photos Library API",
+	}
+
+	options := &googlePhotosAPI.Options{}
+	options.AddFromGooglePhotosAPIFlags(cmd.Flags())
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error { //nolint:contextcheck
+		ctx := cmd.Context()
+		log := app.Log()
+		if app.Jnl() == nil {
+			app.SetJnl(fileevent.NewRecorder(app.Log().Logger))
+		}
+		if err := options.Resolve(); err != nil {
+			return err
+		}
+
+		client, err := googlePhotosAPI.NewClient(ctx, log.Logger, *options)
+		if err != nil {
+			return err
+		}
+
+		assets, err := client.Browse(ctx, options.Album)
+		if err != nil {
+			return err
+		}
+		return uploadAssets(ctx, app, assets)
+	}
+	return cmd
+}
+
+// uploadAssets pushes every asset coming out of assetChan to the Immich
+// server configured on app, logging each asset that cannot be opened or
+// uploaded.
+func uploadAssets(ctx context.Context, app *application.Application, assetChan chan *googlePhotosAPI.Asset) error {
+	jnl := app.Jnl()
+	for asset := range assetChan {
+		r, err := asset.Open(ctx)
+		if err != nil {
+			jnl.Log().Error("opening asset: " + err.Error())
+			continue
+		}
+		err = app.Client().UploadAsset(ctx, asset.Metadata, r)
+		r.Close()
+		if err != nil {
+			jnl.Log().Error("uploading asset: " + err.Error())
+			continue
+		}
+	}
+	return nil
+}
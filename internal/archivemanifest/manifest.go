@@ -0,0 +1,180 @@
+// Package archivemanifest implements the on-disk journal that lets `archive`
+// commands skip assets they have already written out, resume after a crash
+// mid-run, and later verify that the destination still matches what was
+// recorded.
+package archivemanifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileName is the manifest's name under the --write-to-folder directory.
+const FileName = ".immich-go-archive.json"
+
+// Status records the outcome of archiving one asset.
+type Status string
+
+const (
+	StatusOK      Status = "ok"
+	StatusSkipped Status = "skipped"
+	StatusFailed  Status = "failed"
+)
+
+// Entry is one asset's journal line: the key it was found under, enough of
+// the source file's identity to detect that it changed, and where (and how)
+// it ended up in the archive.
+type Entry struct {
+	Key           string    `json:"key"`               // GoogleMetaData.Key() of the paired sidecar, the Library API media item ID, or else the media file's path within its source
+	SourceSize    int64     `json:"sourceSize"`        // source file size, to detect drift between runs
+	SourceModTime time.Time `json:"sourceModTime"`     // source file mtime, to detect drift between runs
+	Filename      string    `json:"filename"`          // final name chosen in the archive, after collision handling
+	Sidecar       string    `json:"sidecar,omitempty"` // name of the JSON sidecar this entry was paired with, if any
+	SHA256        string    `json:"sha256,omitempty"`  // hash of the archived file, checked by `archive verify`
+	Status        Status    `json:"status"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// unchanged reports whether e still describes the same source file as the
+// one that produced a size/mtime pair, so a prior run's entry can be
+// trusted without re-reading the file's content.
+func (e Entry) unchanged(size int64, modTime time.Time) bool {
+	return e.SourceSize == size && e.SourceModTime.Equal(modTime)
+}
+
+// Manifest is the in-memory form of the journal, keyed by Entry.Key.
+type Manifest struct {
+	path    string
+	entries map[string]Entry
+}
+
+// Load reads the manifest from dir, returning an empty one if it doesn't
+// exist yet.
+func Load(dir string) (*Manifest, error) {
+	m := &Manifest{
+		path:    filepath.Join(dir, FileName),
+		entries: map[string]Entry{},
+	}
+
+	b, err := os.ReadFile(m.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, nil
+		}
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		m.entries[e.Key] = e
+	}
+	return m, nil
+}
+
+// Save writes the manifest back to disk, sorted isn't required since it's
+// keyed by map and re-read into a map on Load.
+func (m *Manifest) Save() error {
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(m.path, b, 0o644)
+}
+
+// Processed reports whether key was already archived from a source file
+// that still has the given size and modification time, so the caller can
+// skip re-processing it.
+func (m *Manifest) Processed(key string, size int64, modTime time.Time) bool {
+	e, ok := m.entries[key]
+	return ok && e.Status == StatusOK && e.unchanged(size, modTime)
+}
+
+// Done reports whether key was already archived successfully, without
+// comparing size or modification time. It's for sources such as the Google
+// Photos API that have no local file to stat, so unlike Processed it can
+// only tell that an asset with this key succeeded before, not that the
+// remote asset is still the same bytes.
+func (m *Manifest) Done(key string) bool {
+	e, ok := m.entries[key]
+	return ok && e.Status == StatusOK
+}
+
+// Put records the outcome of archiving one asset.
+func (m *Manifest) Put(e Entry) {
+	m.entries[e.Key] = e
+}
+
+// Failed returns every entry whose last attempt did not succeed.
+func (m *Manifest) Failed() []Entry {
+	var failed []Entry
+	for _, e := range m.entries {
+		if e.Status == StatusFailed {
+			failed = append(failed, e)
+		}
+	}
+	return failed
+}
+
+// Entries returns every entry in the manifest.
+func (m *Manifest) Entries() []Entry {
+	entries := make([]Entry, 0, len(m.entries))
+	for _, e := range m.entries {
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// VerifyResult is the outcome of re-hashing one manifest entry's file
+// against the destination file system.
+type VerifyResult struct {
+	Entry   Entry
+	Drifted bool   // the destination file no longer matches what was archived
+	Error   string // set when the destination file is missing or unreadable
+}
+
+// Verify re-hashes each OK entry's destination file and reports any that no
+// longer match the hash recorded for it, or have gone missing.
+func Verify(destFS fs.FS, m *Manifest) ([]VerifyResult, error) {
+	results := make([]VerifyResult, 0, len(m.entries))
+	for _, e := range m.entries {
+		if e.Status != StatusOK {
+			continue
+		}
+		hash, err := HashFile(destFS, e.Filename)
+		if err != nil {
+			results = append(results, VerifyResult{Entry: e, Error: err.Error()})
+			continue
+		}
+		results = append(results, VerifyResult{Entry: e, Drifted: e.SHA256 != "" && hash != e.SHA256})
+	}
+	return results, nil
+}
+
+// HashFile computes the SHA-256 of a file in destFS, for recording in an
+// Entry.SHA256 at archive time or re-checking it with Verify.
+func HashFile(destFS fs.FS, name string) (string, error) {
+	f, err := destFS.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,117 @@
+package archivemanifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestManifestLoadSaveRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load (missing file): %v", err)
+	}
+	if len(m.Entries()) != 0 {
+		t.Fatalf("Entries() on empty manifest = %d, want 0", len(m.Entries()))
+	}
+
+	modTime := time.Now().Truncate(time.Second)
+	m.Put(Entry{Key: "a.jpg", SourceSize: 123, SourceModTime: modTime, Filename: "a.jpg", Status: StatusOK})
+	m.Put(Entry{Key: "b.jpg", Status: StatusFailed, Error: "boom"})
+
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	reloaded, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load (after save): %v", err)
+	}
+	if !reloaded.Processed("a.jpg", 123, modTime) {
+		t.Errorf("Processed(a.jpg) = false, want true after reload")
+	}
+	if len(reloaded.Failed()) != 1 || reloaded.Failed()[0].Key != "b.jpg" {
+		t.Errorf("Failed() = %+v, want one entry for b.jpg", reloaded.Failed())
+	}
+}
+
+func TestManifestProcessedDetectsDrift(t *testing.T) {
+	m := &Manifest{entries: map[string]Entry{}}
+	modTime := time.Now().Truncate(time.Second)
+	m.Put(Entry{Key: "a.jpg", SourceSize: 100, SourceModTime: modTime, Status: StatusOK})
+
+	if !m.Processed("a.jpg", 100, modTime) {
+		t.Errorf("Processed() = false, want true for unchanged source")
+	}
+	if m.Processed("a.jpg", 101, modTime) {
+		t.Errorf("Processed() = true, want false when size changed")
+	}
+	if m.Processed("a.jpg", 100, modTime.Add(time.Second)) {
+		t.Errorf("Processed() = true, want false when modTime changed")
+	}
+	if m.Processed("missing.jpg", 100, modTime) {
+		t.Errorf("Processed() = true for a key never recorded")
+	}
+}
+
+func TestManifestDoneIgnoresSourceIdentity(t *testing.T) {
+	m := &Manifest{entries: map[string]Entry{}}
+	m.Put(Entry{Key: "mediaItemID", Status: StatusOK})
+
+	if !m.Done("mediaItemID") {
+		t.Errorf("Done() = false, want true for a recorded OK entry")
+	}
+	if m.Done("other") {
+		t.Errorf("Done() = true for a key never recorded")
+	}
+
+	m.Put(Entry{Key: "failedItem", Status: StatusFailed})
+	if m.Done("failedItem") {
+		t.Errorf("Done() = true for a failed entry, want false")
+	}
+}
+
+func TestVerifyDetectsDriftAndMissingFiles(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "present.jpg"), []byte("hello"), 0o644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	destFS := os.DirFS(dir)
+	hash, err := HashFile(destFS, "present.jpg")
+	if err != nil {
+		t.Fatalf("HashFile: %v", err)
+	}
+
+	m := &Manifest{entries: map[string]Entry{
+		"present": {Key: "present", Filename: "present.jpg", SHA256: hash, Status: StatusOK},
+		"stale":   {Key: "stale", Filename: "present.jpg", SHA256: "not-the-real-hash", Status: StatusOK},
+		"missing": {Key: "missing", Filename: "gone.jpg", Status: StatusOK},
+	}}
+
+	results, err := Verify(destFS, m)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+
+	byKey := map[string]VerifyResult{}
+	for _, r := range results {
+		byKey[r.Entry.Key] = r
+	}
+
+	if byKey["present"].Drifted || byKey["present"].Error != "" {
+		t.Errorf("present entry = %+v, want no drift and no error", byKey["present"])
+	}
+	if !byKey["stale"].Drifted {
+		t.Errorf("stale entry = %+v, want Drifted true", byKey["stale"])
+	}
+	if byKey["missing"].Error == "" {
+		t.Errorf("missing entry = %+v, want an Error", byKey["missing"])
+	}
+}